@@ -2,13 +2,17 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"math/rand"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/github/git-lfs/test"
 
@@ -23,7 +27,13 @@ type TestObject struct {
 
 type ServerTest struct {
 	Name string
-	F    func(oidsExist, oidsMissing []TestObject) error
+	// F runs the test and returns the OIDs it actually exercised (for
+	// the test report), along with any failure.
+	F func(ctx context.Context, oidsExist, oidsMissing []TestObject) (oids []string, err error)
+	// Parallel marks a test as safe to run concurrently with other
+	// parallel tests. Tests that mutate server state should leave this
+	// false so they run with exclusive access to the server.
+	Parallel bool
 }
 
 var (
@@ -35,7 +45,20 @@ var (
 	apiUrl   string
 	cloneUrl string
 
+	authModeFlag string
+	token        string
+	spFile       string
+	msiEndpoint  string
+
+	reportFormat string
+	reportFile   string
+
+	parallel int
+	timeout  time.Duration
+
 	tests []ServerTest
+
+	printMu sync.Mutex
 )
 
 func main() {
@@ -53,6 +76,10 @@ func testServerApi(cmd *cobra.Command, args []string) {
 		exit("Must supply either no file arguments or both the exists AND missing file")
 	}
 
+	if parallel < 1 {
+		parallel = 1
+	}
+
 	// Configure the endpoint manually
 	var endp lfs.Endpoint
 	if len(cloneUrl) > 0 {
@@ -62,6 +89,13 @@ func testServerApi(cmd *cobra.Command, args []string) {
 	}
 	lfs.Config.SetManualEndpoint(endp)
 
+	// Only --auth-mode=basic (the default) is actually functional; other
+	// modes fail fast here rather than accepting the flags and then
+	// running unauthenticated. See NewAuthenticator.
+	if _, err := NewAuthenticator(AuthMode(authModeFlag), token, spFile, msiEndpoint); err != nil {
+		exit(err.Error())
+	}
+
 	var oidsExist, oidsMissing []TestObject
 	if len(args) >= 2 {
 		fmt.Printf("Reading test data from files (no server content changes)\n")
@@ -75,7 +109,19 @@ func testServerApi(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	runTests(oidsExist, oidsMissing)
+	results := runTests(oidsExist, oidsMissing)
+
+	if reportFormat != "text" {
+		if err := writeReport(reportFormat, reportFile, results); err != nil {
+			exit("Failed to write %s report: %s", reportFormat, err)
+		}
+	}
+
+	for _, r := range results {
+		if !r.Passed {
+			os.Exit(1)
+		}
+	}
 }
 
 func readTestOids(filename string) []TestObject {
@@ -165,16 +211,56 @@ func buildTestData() (oidsExist, oidsMissing []TestObject, err error) {
 	return oidsExist, oidsMissing, nil
 }
 
-func runTests(oidsExist, oidsMissing []TestObject) {
+func runTests(oidsExist, oidsMissing []TestObject) []TestResult {
+
+	fmt.Printf("Running %d tests (parallel=%d)...\n", len(tests), parallel)
+
+	results := make([]TestResult, len(tests))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+
+	for i, t := range tests {
+		if !t.Parallel {
+			// Exclusive test: let any in-flight parallel tests
+			// finish first, and wait for the test itself to
+			// actually finish (even past a timeout), so it truly
+			// has the server to itself.
+			wg.Wait()
+			result, done := runTest(t, oidsExist, oidsMissing)
+			<-done
+			results[i] = result
+			continue
+		}
 
-	fmt.Printf("Running %d tests...\n", len(tests))
-	for _, t := range tests {
-		runTest(t, oidsExist, oidsMissing)
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, t ServerTest) {
+			defer wg.Done()
+			result, done := runTest(t, oidsExist, oidsMissing)
+			results[i] = result
+			// Only free the slot once the test goroutine has
+			// actually returned. A timed-out test is reported as
+			// failed right away by runTest, but its F may still
+			// be running in the background; releasing the
+			// semaphore at that point would let real concurrency
+			// exceed --parallel=N.
+			<-done
+			<-sem
+		}(i, t)
 	}
+	wg.Wait()
+
+	printSummary(results)
 
+	return results
 }
 
-func runTest(t ServerTest, oidsExist, oidsMissing []TestObject) error {
+// runTest runs a single ServerTest and returns its result along with a
+// channel that's closed once t.F has actually returned. Most callers can
+// ignore the channel; it exists so a timed-out test's caller can still
+// wait for the leaked goroutine to finish before treating its resources
+// (e.g. a semaphore slot) as free.
+func runTest(t ServerTest, oidsExist, oidsMissing []TestObject) (TestResult, <-chan struct{}) {
 	const linelen = 70
 	line := t.Name
 	if len(line) > linelen {
@@ -182,16 +268,95 @@ func runTest(t ServerTest, oidsExist, oidsMissing []TestObject) error {
 	} else if len(line) < linelen {
 		line = fmt.Sprintf("%s%s", line, strings.Repeat(" ", linelen-len(line)))
 	}
-	fmt.Printf("%s...\r", line)
+	if reportFormat == "text" {
+		printMu.Lock()
+		fmt.Printf("%s...\r", line)
+		printMu.Unlock()
+	}
 
-	err := t.F(oidsExist, oidsMissing)
-	if err != nil {
-		fmt.Printf("%s FAILED\n", line)
-		fmt.Println(err.Error())
-	} else {
-		fmt.Printf("%s OK\n", line)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan struct{})
+	resultc := make(chan testOutcome, 1)
+	go func() {
+		oids, err := t.F(ctx, oidsExist, oidsMissing)
+		resultc <- testOutcome{oids: oids, err: err}
+		close(done)
+	}()
+
+	var outcome testOutcome
+	select {
+	case outcome = <-resultc:
+	case <-ctx.Done():
+		outcome.err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	result := TestResult{
+		Name:     t.Name,
+		Passed:   outcome.err == nil,
+		Duration: time.Since(start),
+		Oids:     outcome.oids,
+	}
+
+	if outcome.err != nil {
+		result.Error = outcome.err.Error()
+	}
+
+	if reportFormat == "text" {
+		printMu.Lock()
+		if outcome.err != nil {
+			fmt.Printf("%s FAILED\n", line)
+			fmt.Println(outcome.err.Error())
+		} else {
+			fmt.Printf("%s OK\n", line)
+		}
+		printMu.Unlock()
+	}
+	return result, done
+}
+
+// testOutcome is what a ServerTest.F goroutine reports back: the OIDs it
+// actually exercised and any failure.
+type testOutcome struct {
+	oids []string
+	err  error
+}
+
+// printSummary prints aggregated pass/fail counts and the slowest tests,
+// so a long parallel run still ends with something scannable.
+func printSummary(results []TestResult) {
+	if reportFormat != "text" {
+		return
+	}
+
+	var passed, failed int
+	for _, r := range results {
+		if r.Passed {
+			passed++
+		} else {
+			failed++
+		}
+	}
+
+	slowest := append([]TestResult(nil), results...)
+	sort.Slice(slowest, func(i, j int) bool { return slowest[i].Duration > slowest[j].Duration })
+
+	const slowestN = 5
+	if len(slowest) > slowestN {
+		slowest = slowest[:slowestN]
+	}
+
+	fmt.Printf("\n%d passed, %d failed\n", passed, failed)
+	fmt.Println("Slowest tests:")
+	for _, r := range slowest {
+		fmt.Printf("  %-70s %s\n", r.Name, r.Duration)
 	}
-	return err
 }
 
 // Exit prints a formatted message and exits.
@@ -203,4 +368,12 @@ func exit(format string, args ...interface{}) {
 func init() {
 	RootCmd.Flags().StringVarP(&apiUrl, "url", "u", "", "URL of the API (must supply this or --clone)")
 	RootCmd.Flags().StringVarP(&cloneUrl, "clone", "c", "", "Clone URL from which to find API (must supply this or --url)")
+	RootCmd.Flags().StringVar(&authModeFlag, "auth-mode", "", "Authentication mode (default basic); bearer, ntlm, azure-sp and azure-msi are recognised but rejected with an error")
+	RootCmd.Flags().StringVar(&token, "token", "", "Bearer token for --auth-mode=bearer (unused, since that mode is rejected)")
+	RootCmd.Flags().StringVar(&spFile, "sp-file", "", "Path to a JSON file with tenant/client/secret, for --auth-mode=azure-sp (unused, since that mode is rejected)")
+	RootCmd.Flags().StringVar(&msiEndpoint, "msi-endpoint", "", "Managed identity token endpoint, for --auth-mode=azure-msi (unused, since that mode is rejected)")
+	RootCmd.Flags().StringVar(&reportFormat, "report-format", "text", "Result output format: text, json or junit")
+	RootCmd.Flags().StringVar(&reportFile, "report-file", "", "File to write the report to (default stdout, ignored for --report-format=text)")
+	RootCmd.Flags().IntVar(&parallel, "parallel", 1, "Number of tests to run concurrently (tests that need exclusive access always run alone)")
+	RootCmd.Flags().DurationVar(&timeout, "timeout", 0, "Per-test timeout, e.g. 30s (0 disables)")
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+)
+
+// reportSchemaVersion is bumped whenever the shape of the JSON report
+// changes in a way that isn't backwards compatible, so downstream tooling
+// can detect and handle old reports.
+const reportSchemaVersion = 1
+
+// TestResult is the outcome of running a single ServerTest.
+type TestResult struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Duration time.Duration `json:"durationNanos"`
+	Error    string        `json:"error,omitempty"`
+	// Oids are the specific OIDs this test exercised, as reported by
+	// ServerTest.F, not the full fixture set passed into the run.
+	Oids []string `json:"oids"`
+}
+
+// TestReport is the top level JSON document written by --report-format=json.
+type TestReport struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	Results       []TestResult `json:"results"`
+}
+
+// writeReport renders results in the given format to file, or to stdout
+// when file is empty. format "text" is handled by the caller since it's
+// streamed as tests run rather than written at the end.
+func writeReport(format, file string, results []TestResult) error {
+	var data []byte
+	var err error
+
+	switch format {
+	case "json":
+		data, err = json.MarshalIndent(TestReport{SchemaVersion: reportSchemaVersion, Results: results}, "", "  ")
+	case "junit":
+		data, err = xml.MarshalIndent(newJUnitTestSuite(results), "", "  ")
+		if err == nil {
+			data = append([]byte(xml.Header), data...)
+		}
+	default:
+		return fmt.Errorf("unknown --report-format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(file) == 0 {
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+
+	return writeFile(file, data)
+}
+
+func writeFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// JUnit XML schema, as understood by Jenkins/GitLab.
+
+type junitTestSuite struct {
+	XMLName  xml.Name        `xml:"testsuite"`
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     float64         `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name    string        `xml:"name,attr"`
+	Time    float64       `xml:"time,attr"`
+	Failure *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func newJUnitTestSuite(results []TestResult) junitTestSuite {
+	suite := junitTestSuite{
+		Name:  "git-lfs-test-server-api",
+		Tests: len(results),
+	}
+
+	for _, r := range results {
+		suite.Time += r.Duration.Seconds()
+		c := junitTestCase{Name: r.Name, Time: r.Duration.Seconds()}
+		if !r.Passed {
+			suite.Failures++
+			c.Failure = &junitFailure{Message: r.Error, Text: r.Error}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	return suite
+}